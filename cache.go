@@ -0,0 +1,188 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DiskCache is a persistent, content-addressed cache for go doc / go list
+// output, modeled on cmd/go/internal/cache: each entry is a file named by
+// the SHA-256 of its action key, and an index.json alongside it tracks
+// timestamps and sizes so entries can be evicted once MaxBytes is exceeded.
+type DiskCache struct {
+	Dir      string
+	MaxBytes int64
+	TTL      time.Duration
+
+	mu    sync.Mutex
+	index map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	Key       string    `json:"key"`
+	Size      int64     `json:"size"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewDiskCache creates a DiskCache rooted at dir, creating it if necessary,
+// and loads its index. A zero maxBytes disables trimming; a zero ttl
+// disables expiration. Trimming evicts by the time an entry was last
+// written; Get does not refresh that time, so this is not an LRU order.
+func NewDiskCache(dir string, maxBytes int64, ttl time.Duration) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache dir %s: %v", dir, err)
+	}
+	c := &DiskCache{Dir: dir, MaxBytes: maxBytes, TTL: ttl, index: make(map[string]cacheEntry)}
+	if err := c.loadIndex(); err != nil {
+		log.Printf("cache: failed to load index at %s, starting fresh: %v", dir, err)
+	}
+	return c, nil
+}
+
+func (c *DiskCache) indexPath() string {
+	return filepath.Join(c.Dir, "index.json")
+}
+
+func (c *DiskCache) loadIndex() error {
+	data, err := os.ReadFile(c.indexPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var entries []cacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range entries {
+		c.index[e.Key] = e
+	}
+	return nil
+}
+
+func (c *DiskCache) saveIndexLocked() error {
+	entries := make([]cacheEntry, 0, len(c.index))
+	for _, e := range c.index {
+		entries = append(entries, e)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.indexPath(), data, 0o644)
+}
+
+// ActionKey computes the content-addressed cache key for a go subprocess
+// invocation, folding in everything that can change its output: the verb,
+// args, working directory, a hash of go.mod, the go toolchain version, and
+// the target GOOS/GOARCH.
+func ActionKey(verb string, args []string, workdir, goModHash, goVersion string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "verb=%s\nargs=%v\nworkdir=%s\ngomod=%s\ngoversion=%s\ngoos=%s\ngoarch=%s\n",
+		verb, args, workdir, goModHash, goVersion, runtime.GOOS, runtime.GOARCH)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the cached content for key, if present and not expired.
+func (c *DiskCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	entry, ok := c.index[key]
+	c.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+	if c.TTL > 0 && time.Since(entry.Timestamp) > c.TTL {
+		return "", false
+	}
+	data, err := os.ReadFile(filepath.Join(c.Dir, key))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Put stores content under key and trims the cache if it now exceeds
+// MaxBytes.
+func (c *DiskCache) Put(key, content string) error {
+	if err := os.WriteFile(filepath.Join(c.Dir, key), []byte(content), 0o644); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.index[key] = cacheEntry{Key: key, Size: int64(len(content)), Timestamp: time.Now()}
+	err := c.saveIndexLocked()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if c.MaxBytes > 0 {
+		c.trim()
+	}
+	return nil
+}
+
+// trim evicts the least recently written entries until the cache is back
+// under MaxBytes.
+func (c *DiskCache) trim() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total int64
+	entries := make([]cacheEntry, 0, len(c.index))
+	for _, e := range c.index {
+		entries = append(entries, e)
+		total += e.Size
+	}
+	if total <= c.MaxBytes {
+		return
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	for _, e := range entries {
+		if total <= c.MaxBytes {
+			break
+		}
+		os.Remove(filepath.Join(c.Dir, e.Key))
+		delete(c.index, e.Key)
+		total -= e.Size
+	}
+	if err := c.saveIndexLocked(); err != nil {
+		log.Printf("cache: failed to save index after trim: %v", err)
+	}
+}
+
+// Clear removes every entry from the cache. It backs the go_cache_clear
+// MCP tool.
+func (c *DiskCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.index {
+		os.Remove(filepath.Join(c.Dir, key))
+	}
+	c.index = make(map[string]cacheEntry)
+	return c.saveIndexLocked()
+}
+
+// goModHash returns the hex SHA-256 of go.mod in workdir, or "none" if workdir
+// has no go.mod (e.g. a GOPATH-style tree or a plain stdlib lookup).
+func goModHash(workdir string) string {
+	data, err := os.ReadFile(filepath.Join(workdir, "go.mod"))
+	if err != nil {
+		return "none"
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
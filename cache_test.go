@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskCacheGetPutRoundTrip(t *testing.T) {
+	c, err := NewDiskCache(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache: %v", err)
+	}
+
+	if _, ok := c.Get("missing"); ok {
+		t.Fatalf("Get on empty cache returned ok=true")
+	}
+
+	if err := c.Put("key1", "hello"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	content, ok := c.Get("key1")
+	if !ok || content != "hello" {
+		t.Fatalf("Get(key1) = (%q, %v), want (\"hello\", true)", content, ok)
+	}
+}
+
+func TestDiskCacheTTLExpiry(t *testing.T) {
+	c, err := NewDiskCache(t.TempDir(), 0, time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewDiskCache: %v", err)
+	}
+	if err := c.Put("key1", "hello"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := c.Get("key1"); ok {
+		t.Fatalf("Get(key1) returned ok=true after TTL expired")
+	}
+}
+
+func TestDiskCacheTrimEvictsOldestFirst(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewDiskCache(dir, 15, 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache: %v", err)
+	}
+
+	// Each Put after MaxBytes is exceeded triggers a trim, so write entries
+	// one at a time and backdate their timestamps to control eviction order
+	// deterministically (they'd otherwise all share one time.Now() tick).
+	// oldest (10 bytes) + newest (6 bytes) = 16 bytes, which genuinely
+	// exceeds MaxBytes=15 and so must trigger a trim (trim() only evicts
+	// when total > MaxBytes, not at exactly the cap).
+	if err := c.Put("oldest", "1234567890"); err != nil {
+		t.Fatalf("Put(oldest): %v", err)
+	}
+	c.mu.Lock()
+	e := c.index["oldest"]
+	e.Timestamp = time.Now().Add(-time.Hour)
+	c.index["oldest"] = e
+	c.mu.Unlock()
+
+	if err := c.Put("newest", "123456"); err != nil {
+		t.Fatalf("Put(newest): %v", err)
+	}
+
+	if _, ok := c.Get("oldest"); ok {
+		t.Fatalf("oldest entry survived trim, want it evicted")
+	}
+	if _, ok := c.Get("newest"); !ok {
+		t.Fatalf("newest entry was evicted, want it kept")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "oldest")); !os.IsNotExist(err) {
+		t.Fatalf("oldest entry's file still exists on disk after trim (stat err: %v)", err)
+	}
+}
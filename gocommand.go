@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// Invocation describes a single `go` subprocess to run. It is the shared
+// shape every tool in this server uses to build its command line, instead
+// of each handler hand-rolling its own exec.Command.
+type Invocation struct {
+	Verb       string   // "doc", "list", "build", "vet", "test", "mod", ...
+	Args       []string // arguments after the verb
+	WorkingDir string   // cmd.Dir; if empty, the server process's current directory
+	Env        []string // extra "K=V" entries appended to the process environment
+	BuildFlags []string // e.g. "-tags=integration"; inserted before Args
+	ModFlag    string   // "-mod=mod", "-mod=readonly", ...
+}
+
+// key returns the string that identifies this invocation for deduping
+// purposes: two Invocations with the same key are assumed to produce the
+// same output.
+func (i Invocation) key() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s|%s|%v|%v|%v|%s", i.Verb, i.WorkingDir, i.Args, i.BuildFlags, i.Env, i.ModFlag)
+	return buf.String()
+}
+
+func (i Invocation) args() []string {
+	args := make([]string, 0, len(i.BuildFlags)+len(i.Args)+2)
+	args = append(args, i.Verb)
+	args = append(args, i.BuildFlags...)
+	if i.ModFlag != "" {
+		args = append(args, i.ModFlag)
+	}
+	args = append(args, i.Args...)
+	return args
+}
+
+// invocationResult is what a Runner caches/fans out to every caller waiting
+// on the same in-flight Invocation.
+type invocationResult struct {
+	stdout []byte
+	stderr []byte
+	err    error
+}
+
+// inFlightCall tracks a single running Invocation; done is closed once res
+// is populated, so any number of waiters can receive the same result. The
+// subprocess behind it always runs with context.Background(), independent
+// of any individual caller's context (see Run) — so one caller's context
+// being canceled can't kill the subprocess, and misattribute that
+// cancellation as an error, for every other caller piggybacking on the same
+// Invocation.
+type inFlightCall struct {
+	done chan struct{}
+	res  invocationResult
+}
+
+// Runner deduplicates `go` subprocess invocations. If two
+// callers request the identical Invocation concurrently, only one `go`
+// process is started and both callers receive its result; this matters for
+// tools like go_doc where an AI client may issue the same lookup from
+// several tool calls in flight at once.
+type Runner struct {
+	mu       sync.Mutex
+	inFlight map[string]*inFlightCall
+}
+
+// NewRunner creates a Runner ready for use.
+func NewRunner() *Runner {
+	return &Runner{inFlight: make(map[string]*inFlightCall)}
+}
+
+// Run executes inv, piggybacking on an identical in-flight invocation if one
+// exists, and returns its combined stdout/stderr split apart (unlike
+// CombinedOutput, stderr is kept separate so callers can decide whether to
+// surface it as diagnostic detail). If ctx is canceled before the result is
+// ready, Run returns ctx.Err() to this caller only — the underlying
+// subprocess keeps running to completion for the benefit of any other
+// caller piggybacking on the same Invocation (see inFlightCall).
+func (r *Runner) Run(ctx context.Context, inv Invocation) (stdout, stderr []byte, err error) {
+	key := inv.key()
+
+	r.mu.Lock()
+	call, existed := r.inFlight[key]
+	if !existed {
+		call = &inFlightCall{done: make(chan struct{})}
+		r.inFlight[key] = call
+		go r.runInFlight(key, call, inv)
+	} else {
+		log.Printf("gocommand: piggybacking on in-flight `go %v`", inv.args())
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.res.stdout, call.res.stderr, call.res.err
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+// runInFlight runs inv to completion and publishes its result to call. It
+// always uses context.Background(), not any individual caller's context
+// (see Run), since the subprocess is shared by every caller piggybacking on
+// this Invocation.
+func (r *Runner) runInFlight(key string, call *inFlightCall, inv Invocation) {
+	call.res = r.run(context.Background(), inv)
+	close(call.done)
+
+	r.mu.Lock()
+	delete(r.inFlight, key)
+	r.mu.Unlock()
+}
+
+func (r *Runner) run(ctx context.Context, inv Invocation) invocationResult {
+	log.Printf("gocommand: running `go %v` in %s", inv.args(), inv.WorkingDir)
+
+	cmd := exec.CommandContext(ctx, "go", inv.args()...)
+	cmd.Dir = inv.WorkingDir
+	if len(inv.Env) > 0 {
+		// Start from the parent environment so extra entries (e.g.
+		// GOOS/GOARCH overrides) augment PATH/HOME/GOCACHE/GOROOT
+		// instead of replacing them outright.
+		cmd.Env = append(os.Environ(), inv.Env...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		err = fmt.Errorf("go %s: %v\nstderr: %s", inv.Verb, err, stderr.String())
+	}
+	return invocationResult{stdout: stdout.Bytes(), stderr: stderr.Bytes(), err: err}
+}
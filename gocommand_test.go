@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInvocationKey(t *testing.T) {
+	base := Invocation{Verb: "doc", Args: []string{"fmt.Println"}, WorkingDir: "/tmp/work"}
+
+	same := base
+	if base.key() != same.key() {
+		t.Errorf("identical invocations produced different keys: %q vs %q", base.key(), same.key())
+	}
+
+	variants := []Invocation{
+		{Verb: "list", Args: base.Args, WorkingDir: base.WorkingDir},
+		{Verb: base.Verb, Args: []string{"fmt.Printf"}, WorkingDir: base.WorkingDir},
+		{Verb: base.Verb, Args: base.Args, WorkingDir: "/tmp/other"},
+		{Verb: base.Verb, Args: base.Args, WorkingDir: base.WorkingDir, Env: []string{"GOOS=linux"}},
+		{Verb: base.Verb, Args: base.Args, WorkingDir: base.WorkingDir, BuildFlags: []string{"-tags=integration"}},
+		{Verb: base.Verb, Args: base.Args, WorkingDir: base.WorkingDir, ModFlag: "-mod=mod"},
+	}
+	for _, v := range variants {
+		if v.key() == base.key() {
+			t.Errorf("invocation %+v should not share a key with %+v, got %q for both", v, base, base.key())
+		}
+	}
+}
+
+// TestRunnerDoesNotMisattributeCancellation verifies that when two callers
+// piggyback on the same in-flight Invocation, one caller's own context being
+// canceled returns that caller's ctx.Err() without affecting the other
+// caller, which still gets the real result (see Run's doc comment).
+func TestRunnerDoesNotMisattributeCancellation(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not on PATH")
+	}
+	r := NewRunner()
+	inv := Invocation{Verb: "version"}
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel() // already canceled before Run ever sees it
+
+	var wg sync.WaitGroup
+	var liveErr, canceledErr error
+	var liveStdout []byte
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		liveStdout, _, liveErr = r.Run(context.Background(), inv)
+	}()
+	go func() {
+		defer wg.Done()
+		_, _, canceledErr = r.Run(canceledCtx, inv)
+	}()
+	wg.Wait()
+
+	if canceledErr != context.Canceled {
+		t.Errorf("canceled caller's error = %v, want context.Canceled", canceledErr)
+	}
+	if liveErr != nil {
+		t.Errorf("live caller's error = %v, want nil (go version should succeed)", liveErr)
+	}
+	if len(liveStdout) == 0 {
+		t.Errorf("live caller's stdout is empty, want `go version` output")
+	}
+
+	// The in-flight entry must be cleaned up once the real run finishes,
+	// regardless of how many callers gave up early.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		r.mu.Lock()
+		_, stillThere := r.inFlight[inv.key()]
+		r.mu.Unlock()
+		if !stillThere {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("inFlight entry for %q was never cleaned up", inv.key())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
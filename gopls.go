@@ -0,0 +1,565 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// GoplsClient manages a single `gopls serve` subprocess and speaks the LSP
+// base protocol (Content-Length framed JSON-RPC) with it over stdio. It is
+// started lazily on first use and reused for the lifetime of the server.
+type GoplsClient struct {
+	workdir string
+
+	// startMu guards process lifecycle (spawning gopls and the
+	// initialize/initialized handshake). It is held across ensureStarted's
+	// body, which in turn calls call()/notify() — those must use a
+	// different lock (writeMu) for the actual wire I/O, or a second caller
+	// blocked on startMu while the first is mid-handshake would never let
+	// the handshake's own writeMessage calls through.
+	startMu sync.Mutex
+	started bool
+
+	writeMu sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	nextID  int64
+
+	pending   map[int64]chan *lspResponse
+	pendingMu sync.Mutex
+}
+
+type lspRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int64       `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type lspResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewGoplsClient creates a client rooted at workdir. The gopls process is
+// not started until the first call that needs it.
+func NewGoplsClient(workdir string) *GoplsClient {
+	return &GoplsClient{
+		workdir: workdir,
+		pending: make(map[int64]chan *lspResponse),
+	}
+}
+
+// ensureStarted spawns `gopls serve` and performs the initialize/initialized
+// handshake against g.workdir if it hasn't been done yet.
+func (g *GoplsClient) ensureStarted() error {
+	g.startMu.Lock()
+	defer g.startMu.Unlock()
+
+	if g.started {
+		return nil
+	}
+
+	cmd := exec.Command("gopls", "serve", "-mode=stdio")
+	cmd.Dir = g.workdir
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("gopls: stdin pipe: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("gopls: stdout pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("gopls: start: %v (is gopls installed and on PATH?)", err)
+	}
+
+	g.cmd = cmd
+	g.stdin = stdin
+	g.stdout = bufio.NewReader(stdout)
+
+	go g.readLoop()
+
+	rootURI := pathToURI(g.workdir)
+	initParams := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   rootURI,
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"definition":     map[string]interface{}{},
+				"references":     map[string]interface{}{},
+				"implementation": map[string]interface{}{},
+				"hover":          map[string]interface{}{},
+			},
+			"workspace": map[string]interface{}{
+				"symbol": map[string]interface{}{},
+			},
+		},
+	}
+	// started is only set once the handshake actually succeeds: if either
+	// call below fails, we kill the half-initialized process and leave
+	// started false so the next ensureStarted call respawns from scratch,
+	// instead of wedging every future LSP call against a dead/half-baked
+	// client forever.
+	if _, err := g.call("initialize", initParams); err != nil {
+		g.abandonLocked()
+		return fmt.Errorf("gopls: initialize: %v", err)
+	}
+	if err := g.notify("initialized", map[string]interface{}{}); err != nil {
+		g.abandonLocked()
+		return fmt.Errorf("gopls: initialized: %v", err)
+	}
+	g.started = true
+	return nil
+}
+
+// readLoop reads framed messages from gopls and dispatches responses to
+// whichever call() is waiting on the matching ID. Server->client requests
+// and notifications are discarded.
+func (g *GoplsClient) readLoop() {
+	for {
+		msg, err := readLSPMessage(g.stdout)
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("gopls: read loop error: %v", err)
+			}
+			return
+		}
+
+		var resp lspResponse
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			continue
+		}
+		if resp.ID == 0 {
+			continue // notification or malformed
+		}
+		// A server->client request carries a method and an ID from gopls's
+		// own sequence, which may equal a pending call's ID.
+		var probe struct {
+			Method string `json:"method"`
+		}
+		if json.Unmarshal(msg, &probe) == nil && probe.Method != "" {
+			continue
+		}
+
+		g.pendingMu.Lock()
+		ch, ok := g.pending[resp.ID]
+		if ok {
+			delete(g.pending, resp.ID)
+		}
+		g.pendingMu.Unlock()
+
+		if ok {
+			ch <- &resp
+		}
+	}
+}
+
+// call issues a JSON-RPC request and blocks for its response.
+func (g *GoplsClient) call(method string, params interface{}) (json.RawMessage, error) {
+	id := atomic.AddInt64(&g.nextID, 1)
+	ch := make(chan *lspResponse, 1)
+
+	g.pendingMu.Lock()
+	g.pending[id] = ch
+	g.pendingMu.Unlock()
+
+	if err := g.writeMessage(lspRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+
+	resp := <-ch
+	if resp.Error != nil {
+		return nil, fmt.Errorf("gopls: %s returned error %d: %s", method, resp.Error.Code, resp.Error.Message)
+	}
+	return resp.Result, nil
+}
+
+// notify sends a JSON-RPC notification (no ID, no response expected).
+func (g *GoplsClient) notify(method string, params interface{}) error {
+	return g.writeMessage(struct {
+		JSONRPC string      `json:"jsonrpc"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params,omitempty"`
+	}{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (g *GoplsClient) writeMessage(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	g.writeMu.Lock()
+	defer g.writeMu.Unlock()
+	if _, err := fmt.Fprintf(g.stdin, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = g.stdin.Write(body)
+	return err
+}
+
+// readLSPMessage reads one Content-Length framed message from r.
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	var contentLength int
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "Content-Length:") {
+			n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "Content-Length:")))
+			if err != nil {
+				return nil, fmt.Errorf("bad Content-Length header %q: %v", line, err)
+			}
+			contentLength = n
+		}
+	}
+	if contentLength == 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+	buf := make([]byte, contentLength)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// abandonLocked kills a gopls process that failed to complete the
+// initialize handshake and clears it so the next ensureStarted respawns
+// from scratch. Callers must hold startMu.
+func (g *GoplsClient) abandonLocked() {
+	if g.cmd != nil && g.cmd.Process != nil {
+		g.cmd.Process.Kill()
+	}
+	g.cmd = nil
+	g.stdin = nil
+	g.stdout = nil
+	g.started = false
+}
+
+func (g *GoplsClient) close() {
+	g.startMu.Lock()
+	defer g.startMu.Unlock()
+	if g.cmd != nil && g.cmd.Process != nil {
+		g.cmd.Process.Kill()
+	}
+}
+
+func pathToURI(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// lspPosition is a file path and a 0-based LSP line/character, as resolved
+// by posFromArgs from either an explicit file/line/col or a Go-style
+// pkg.Sym selector (via `go list -json` + a source scan).
+type lspPosition struct {
+	File string
+	Line int // 0-based
+	Char int // 0-based
+}
+
+var symbolDeclRegexp = `(?m)^(func|type|var|const)\s+(\(\s*\w+\s+\*?%s\s*\)\s+)?%s\b`
+
+// splitSelector splits a "pkg.Sym" selector into its package pattern and
+// symbol name. It mirrors handleGoSymbolDoc in packages.go: split on the
+// last dot, so "net/http.Client" resolves pkg="net/http", sym="Client"
+// (this also does the right thing for domain-qualified import paths like
+// "golang.org/x/tools/go/packages.Package", since the dots in "golang.org"
+// live in an earlier path segment). Method/field selectors like
+// "net/http.Client.Do" put a second dot in the final path segment, which
+// we can't disambiguate from a symbol name — that's rejected explicitly
+// rather than silently resolving to the wrong (enclosing type's) position.
+func splitSelector(selector string) (pkg, sym string, err error) {
+	lastSegment := selector
+	if i := strings.LastIndexByte(selector, '/'); i >= 0 {
+		lastSegment = selector[i+1:]
+	}
+	if strings.Count(lastSegment, ".") > 1 {
+		return "", "", fmt.Errorf("selector %q: method/field selectors (pkg.Sym.Method) are not supported, use pkg.Sym or file/line/col instead", selector)
+	}
+
+	idx := strings.LastIndex(selector, ".")
+	if idx < 0 {
+		return "", "", fmt.Errorf("selector %q must be of the form pkg.Sym", selector)
+	}
+	pkg, sym = selector[:idx], selector[idx+1:]
+	if pkg == "" {
+		pkg = "."
+	}
+	return pkg, sym, nil
+}
+
+// resolveSelector turns a "pkg.Sym" selector into a position by listing the
+// package's files with `go list -json` and scanning them for the top-level
+// declaration. "pkg.Sym.Method" selectors are rejected (see splitSelector).
+func (s *GodocServer) resolveSelector(selector string) (*lspPosition, error) {
+	pkg, sym, err := splitSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	out, _, err := s.runner.Run(context.Background(), Invocation{
+		Verb:       "list",
+		Args:       []string{"-json", pkg},
+		WorkingDir: s.Workdir,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("resolving package %q: %v", pkg, err)
+	}
+
+	var info struct {
+		Dir         string
+		GoFiles     []string
+		TestGoFiles []string
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, fmt.Errorf("parsing go list output for %q: %v", pkg, err)
+	}
+
+	re, err := regexp.Compile(fmt.Sprintf(symbolDeclRegexp, regexp.QuoteMeta(sym), regexp.QuoteMeta(sym)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := append(append([]string{}, info.GoFiles...), info.TestGoFiles...)
+	for _, f := range files {
+		path := filepath.Join(info.Dir, f)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		loc := re.FindIndex(data)
+		if loc == nil {
+			continue
+		}
+		line := strings.Count(string(data[:loc[0]]), "\n")
+		lastNL := strings.LastIndexByte(string(data[:loc[0]]), '\n')
+		char := loc[0] - lastNL - 1
+		return &lspPosition{File: path, Line: line, Char: char}, nil
+	}
+	return nil, fmt.Errorf("symbol %q not found in package %q", sym, pkg)
+}
+
+// posFromArgs resolves a tool call's file/line/col or symbol arguments into
+// an lspPosition. It does not open the document with gopls; callers do that
+// with didOpen.
+func (s *GodocServer) posFromArgs(arguments map[string]interface{}) (*lspPosition, error) {
+	if symbol, ok := getString(arguments, "symbol"); ok && symbol != "" {
+		return s.resolveSelector(symbol)
+	}
+
+	file, ok := getString(arguments, "file")
+	if !ok || file == "" {
+		return nil, fmt.Errorf("either \"symbol\" or \"file\"+\"line\"+\"col\" is required")
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(s.Workdir, file)
+	}
+	line, _ := getString(arguments, "line")
+	col, _ := getString(arguments, "col")
+	lineN, _ := strconv.Atoi(line)
+	colN, _ := strconv.Atoi(col)
+	if lineN > 0 {
+		lineN-- // arguments are 1-based for human convenience
+	}
+	if colN > 0 {
+		colN--
+	}
+	return &lspPosition{File: file, Line: lineN, Char: colN}, nil
+}
+
+func (s *GodocServer) didOpen(pos *lspPosition) error {
+	data, err := os.ReadFile(pos.File)
+	if err != nil {
+		return fmt.Errorf("reading %s: %v", pos.File, err)
+	}
+	return s.gopls.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":        pathToURI(pos.File),
+			"languageId": "go",
+			"version":    1,
+			"text":       string(data),
+		},
+	})
+}
+
+func (s *GodocServer) textDocumentPositionParams(pos *lspPosition) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": pathToURI(pos.File)},
+		"position":     map[string]interface{}{"line": pos.Line, "character": pos.Char},
+	}
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []interface{}{
+			map[string]interface{}{"type": "text", "text": text},
+		},
+	}
+}
+
+// handleGoDefinition implements the go_definition MCP tool.
+func (s *GodocServer) handleGoDefinition(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if err := s.gopls.ensureStarted(); err != nil {
+		return nil, err
+	}
+	pos, err := s.posFromArgs(arguments)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.didOpen(pos); err != nil {
+		return nil, err
+	}
+	result, err := s.gopls.call("textDocument/definition", s.textDocumentPositionParams(pos))
+	if err != nil {
+		return nil, err
+	}
+	return textResult(string(result)), nil
+}
+
+// handleGoReferences implements the go_references MCP tool.
+func (s *GodocServer) handleGoReferences(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if err := s.gopls.ensureStarted(); err != nil {
+		return nil, err
+	}
+	pos, err := s.posFromArgs(arguments)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.didOpen(pos); err != nil {
+		return nil, err
+	}
+	params := s.textDocumentPositionParams(pos)
+	includeDecl := true
+	if v, ok := arguments["includeDeclaration"].(bool); ok {
+		includeDecl = v
+	}
+	params["context"] = map[string]interface{}{"includeDeclaration": includeDecl}
+	result, err := s.gopls.call("textDocument/references", params)
+	if err != nil {
+		return nil, err
+	}
+	return textResult(string(result)), nil
+}
+
+// handleGoImplementations implements the go_implementations MCP tool.
+func (s *GodocServer) handleGoImplementations(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if err := s.gopls.ensureStarted(); err != nil {
+		return nil, err
+	}
+	pos, err := s.posFromArgs(arguments)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.didOpen(pos); err != nil {
+		return nil, err
+	}
+	result, err := s.gopls.call("textDocument/implementation", s.textDocumentPositionParams(pos))
+	if err != nil {
+		return nil, err
+	}
+	return textResult(string(result)), nil
+}
+
+// handleGoHover implements the go_hover MCP tool.
+func (s *GodocServer) handleGoHover(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if err := s.gopls.ensureStarted(); err != nil {
+		return nil, err
+	}
+	pos, err := s.posFromArgs(arguments)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.didOpen(pos); err != nil {
+		return nil, err
+	}
+	result, err := s.gopls.call("textDocument/hover", s.textDocumentPositionParams(pos))
+	if err != nil {
+		return nil, err
+	}
+	return textResult(string(result)), nil
+}
+
+// handleGoSymbols implements the go_symbols MCP tool, a thin wrapper around
+// workspace/symbol.
+func (s *GodocServer) handleGoSymbols(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if err := s.gopls.ensureStarted(); err != nil {
+		return nil, err
+	}
+	query, _ := getString(arguments, "query")
+	result, err := s.gopls.call("workspace/symbol", map[string]interface{}{"query": query})
+	if err != nil {
+		return nil, err
+	}
+	return textResult(string(result)), nil
+}
+
+// positionToolSchema is shared by the single-position LSP tools: each
+// accepts either a Go-style "pkg.Sym" selector or an explicit file/line/col
+// triple. Method/field selectors ("pkg.Sym.Method") aren't supported; use
+// file/line/col for those.
+func positionToolSchema(extra map[string]interface{}) mcp.ToolInputSchema {
+	props := map[string]interface{}{
+		"symbol": map[string]interface{}{
+			"type":        "string",
+			"description": "A Go-style \"pkg.Sym\" selector, e.g. \"net/http.Client\" or \"./internal/foo.Bar\". Resolved via `go list -json`. For a specific method or field, use file/line/col instead.",
+		},
+		"file": map[string]interface{}{
+			"type":        "string",
+			"description": "Path to a Go file, absolute or relative to Workdir. Used together with line/col instead of symbol.",
+		},
+		"line": map[string]interface{}{
+			"type":        "string",
+			"description": "1-based line number, used together with file/col.",
+		},
+		"col": map[string]interface{}{
+			"type":        "string",
+			"description": "1-based column number, used together with file/line.",
+		},
+	}
+	for k, v := range extra {
+		props[k] = v
+	}
+	return mcp.ToolInputSchema{Type: "object", Properties: props}
+}
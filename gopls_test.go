@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestSplitSelector(t *testing.T) {
+	cases := []struct {
+		selector string
+		pkg      string
+		sym      string
+		wantErr  bool
+	}{
+		{selector: "io.Reader", pkg: "io", sym: "Reader"},
+		{selector: "net/http.Client", pkg: "net/http", sym: "Client"},
+		{selector: "golang.org/x/tools/go/packages.Package", pkg: "golang.org/x/tools/go/packages", sym: "Package"},
+		{selector: "./internal/foo.Bar", pkg: "./internal/foo", sym: "Bar"},
+		{selector: ".Bar", pkg: ".", sym: "Bar"},
+		{selector: "net/http.Client.Do", wantErr: true},
+		{selector: "golang.org/x/tools/go/packages.Package.String", wantErr: true},
+		{selector: "NoDot", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		pkg, sym, err := splitSelector(tc.selector)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("splitSelector(%q) = (%q, %q, nil), want error", tc.selector, pkg, sym)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("splitSelector(%q) returned unexpected error: %v", tc.selector, err)
+			continue
+		}
+		if pkg != tc.pkg || sym != tc.sym {
+			t.Errorf("splitSelector(%q) = (%q, %q), want (%q, %q)", tc.selector, pkg, sym, tc.pkg, tc.sym)
+		}
+	}
+}
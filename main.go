@@ -1,18 +1,41 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// defaultMaxCacheBytes is used when GO_MCP_MAX_CACHE_BYTES is unset.
+const defaultMaxCacheBytes = 512 * 1024 * 1024 // 512MB
+
+// defaultCacheTTL is used when GO_MCP_CACHE_TTL is unset.
+const defaultCacheTTL = 24 * time.Hour
+
 func main() {
+	transportFlag := flag.String("transport", os.Getenv("MCP_TRANSPORT"), "MCP transport: \"stdio\" (default), \"sse\", or \"streamable-http\"")
+	addrFlag := flag.String("addr", os.Getenv("MCP_ADDR"), "listen address for the \"sse\"/\"streamable-http\" transports, e.g. \":8080\"")
+	flag.Parse()
+
+	transport := *transportFlag
+	if transport == "" {
+		transport = "stdio"
+	}
+	addr := *addrFlag
+	if addr == "" {
+		addr = ":8080"
+	}
+
 	workdir := os.Getenv("WORKDIR")
 	if workdir == "" {
 		log.Fatal("WORKDIR environment variable is required")
@@ -28,10 +51,51 @@ func main() {
 		server.WithToolCapabilities(true), // Enable tools
 		server.WithLogging(),              // Add logging
 	)
+
+	cacheDir := os.Getenv("GO_MCP_CACHE_DIR")
+	if cacheDir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			log.Fatalf("determining cache directory: %v", err)
+		}
+		cacheDir = filepath.Join(userCacheDir, "go-mcp")
+	}
+	maxCacheBytes := int64(defaultMaxCacheBytes)
+	if v := os.Getenv("GO_MCP_MAX_CACHE_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			maxCacheBytes = n
+		}
+	}
+	cacheTTL := defaultCacheTTL
+	if v := os.Getenv("GO_MCP_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cacheTTL = d
+		}
+	}
+	diskCache, err := NewDiskCache(cacheDir, maxCacheBytes, cacheTTL)
+	if err != nil {
+		log.Fatalf("initializing cache: %v", err)
+	}
+
+	workspaceRoots := []string{workdir}
+	if extra := os.Getenv("GO_MCP_WORKSPACE_ROOTS"); extra != "" {
+		workspaceRoots = append(workspaceRoots, strings.Split(extra, string(os.PathListSeparator))...)
+	}
+	workspaces := NewWorkspaceManager(workspaceRoots...)
+	if err := workspaces.Discover(); err != nil {
+		log.Printf("workspace discovery failed, falling back to single WORKDIR: %v", err)
+	}
+
 	godocServer := &GodocServer{
-		Workdir: workdir,
-		cache:   make(map[string]cachedDoc),
-		server:  s,
+		Workdir:       workdir,
+		server:        s,
+		gopls:         NewGoplsClient(workdir),
+		runner:        NewRunner(),
+		CacheDir:      cacheDir,
+		MaxCacheBytes: maxCacheBytes,
+		CacheTTL:      cacheTTL,
+		diskCache:     diskCache,
+		Workspaces:    workspaces,
 	}
 
 	/*
@@ -81,6 +145,7 @@ func main() {
 						"  -src: Show the source code\n" +
 						"  -u: Show unexported symbols as well as exported",
 				},
+				"workspace": workspaceArgSchema["workspace"],
 			},
 		},
 	}, godocServer.handleGoDoc)
@@ -112,13 +177,171 @@ func main() {
 					},
 					"description": "list of packages to list, github.com/user/repo, ./..., github.com/user/repo/..., github.com/user/repo/module/...",
 				},
+				"workspace": workspaceArgSchema["workspace"],
 			},
 		},
 	}, godocServer.handleGoList)
 
-	// Run server using stdio
-	log.Printf("Starting stdio server...")
-	if err := server.ServeStdio(s); err != nil {
+	// LSP-backed navigation tools, powered by an embedded `gopls serve`
+	// process (see gopls.go). Unlike go_doc, these give precise cross-file
+	// navigation: jump to a definition, find all references, list the
+	// implementations of an interface, search workspace symbols, or hover
+	// for a symbol's type and doc comment.
+	//
+	// Unlike go_doc/go_list/go_vet/go_build/go_test/go_mod/go_package_info/
+	// go_symbol_doc, these tools do NOT accept a "workspace"/"module"
+	// argument: the embedded gopls process is started once against the
+	// server's single top-level Workdir (see NewGoplsClient in main), not
+	// per-module via WorkspaceManager, so they always operate on that one
+	// module even in a multi-module workspace.
+	const noWorkspaceSwitchingNote = " Always operates on the server's primary Workdir; unlike most other tools here, this does not accept a \"workspace\"/\"module\" argument."
+	s.AddTool(mcp.Tool{
+		Name:        "go_definition",
+		Description: "Jump to the definition of a Go symbol. Resolves via an embedded gopls (textDocument/definition)." + noWorkspaceSwitchingNote,
+		InputSchema: positionToolSchema(nil),
+	}, godocServer.handleGoDefinition)
+
+	s.AddTool(mcp.Tool{
+		Name:        "go_references",
+		Description: "List all references to a Go symbol across the module. Resolves via an embedded gopls (textDocument/references)." + noWorkspaceSwitchingNote,
+		InputSchema: positionToolSchema(map[string]interface{}{
+			"includeDeclaration": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Optional: include the declaration itself in the results. Defaults to true.",
+			},
+		}),
+	}, godocServer.handleGoReferences)
+
+	s.AddTool(mcp.Tool{
+		Name:        "go_implementations",
+		Description: "List concrete types implementing an interface (or interfaces implemented by a type). Resolves via an embedded gopls (textDocument/implementation)." + noWorkspaceSwitchingNote,
+		InputSchema: positionToolSchema(nil),
+	}, godocServer.handleGoImplementations)
+
+	s.AddTool(mcp.Tool{
+		Name:        "go_hover",
+		Description: "Show the type signature and doc comment for a Go symbol. Resolves via an embedded gopls (textDocument/hover)." + noWorkspaceSwitchingNote,
+		InputSchema: positionToolSchema(nil),
+	}, godocServer.handleGoHover)
+
+	s.AddTool(mcp.Tool{
+		Name:        "go_symbols",
+		Description: "Search for symbols across the workspace by name. Resolves via an embedded gopls (workspace/symbol)." + noWorkspaceSwitchingNote,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Symbol name or fuzzy query to search for, e.g. \"GodocServer\" or \"handleGo\".",
+				},
+			},
+		},
+	}, godocServer.handleGoSymbols)
+
+	// Structured, type-aware queries backed by golang.org/x/tools/go/packages
+	// (see packages.go). These load the workspace once via packages.Load and
+	// answer from the resulting syntax/type graph instead of shelling out to
+	// `go doc` per query, and return JSON rather than formatted text.
+	s.AddTool(mcp.Tool{
+		Name:        "go_package_info",
+		Description: "Return structured info (imports, files, module path, Go version) for a package pattern, loaded via golang.org/x/tools/go/packages.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "A package pattern, e.g. \".\", \"./internal/foo\", or \"net/http\".",
+				},
+				"workspace": workspaceArgSchema["workspace"],
+				"goos":      crossCompileArgSchema["goos"],
+				"goarch":    crossCompileArgSchema["goarch"],
+			},
+		},
+	}, godocServer.handleGoPackageInfo)
+
+	s.AddTool(mcp.Tool{
+		Name:        "go_symbol_doc",
+		Description: "Return the doc comment, signature, source location, and source snippet for a symbol, loaded via golang.org/x/tools/go/packages.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"symbol": map[string]interface{}{
+					"type":        "string",
+					"description": "A Go-style selector, e.g. \"net/http.Client\" or \"./internal/foo.Bar\".",
+				},
+				"workspace": workspaceArgSchema["workspace"],
+				"goos":      crossCompileArgSchema["goos"],
+				"goarch":    crossCompileArgSchema["goarch"],
+			},
+		},
+	}, godocServer.handleGoSymbolDoc)
+
+	// Toolchain bridge tools (see toolchain.go): vet/build/test/mod, each
+	// returning a human-readable text block plus a structured JSON block
+	// parsed from the underlying command's output.
+	packagesArgSchema := map[string]interface{}{
+		"packages": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "string",
+			},
+			"description": "Packages to operate on, e.g. \"./...\". Defaults to \"./...\" if omitted.",
+		},
+		"workspace": workspaceArgSchema["workspace"],
+	}
+	s.AddTool(mcp.Tool{
+		Name:        "go_vet",
+		Description: "Run go vet and return diagnostics as both text and structured JSON ({file, line, col, severity, message}).",
+		InputSchema: mcp.ToolInputSchema{Type: "object", Properties: packagesArgSchema},
+	}, godocServer.handleGoVet)
+
+	s.AddTool(mcp.Tool{
+		Name:        "go_build",
+		Description: "Run go build (discarding the binary) and return diagnostics as both text and structured JSON ({file, line, col, severity, message}).",
+		InputSchema: mcp.ToolInputSchema{Type: "object", Properties: packagesArgSchema},
+	}, godocServer.handleGoBuild)
+
+	s.AddTool(mcp.Tool{
+		Name:        "go_test",
+		Description: "Run go test -json and return the combined test output as both text and structured JSON test events ({package, test, action, elapsed, output}).",
+		InputSchema: mcp.ToolInputSchema{Type: "object", Properties: packagesArgSchema},
+	}, godocServer.handleGoTest)
+
+	s.AddTool(mcp.Tool{
+		Name:        "go_mod",
+		Description: "Run a go mod subcommand (why, graph, tidy) and return its output as both text and structured JSON (module/edge list for graph).",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"subcommand": map[string]interface{}{
+					"type":        "string",
+					"description": "One of \"why\", \"graph\", \"tidy\".",
+				},
+				"args": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "string",
+					},
+					"description": "Arguments to the subcommand, e.g. the package for \"why\".",
+				},
+				"workspace": workspaceArgSchema["workspace"],
+			},
+		},
+	}, godocServer.handleGoMod)
+
+	s.AddTool(mcp.Tool{
+		Name:        "go_cache_clear",
+		Description: fmt.Sprintf("Clear the on-disk go_doc/go_list cache at %s.", godocServer.CacheDir),
+		InputSchema: mcp.ToolInputSchema{Type: "object", Properties: map[string]interface{}{}},
+	}, godocServer.handleCacheClear)
+
+	s.AddTool(mcp.Tool{
+		Name:        "go_workspaces",
+		Description: "List every module discovered under the server's configured workspace roots, with its import path, directory, and Go version.",
+		InputSchema: mcp.ToolInputSchema{Type: "object", Properties: map[string]interface{}{}},
+	}, godocServer.handleGoWorkspaces)
+
+	if err := serve(s, transport, addr); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 
@@ -126,6 +349,30 @@ func main() {
 	godocServer.cleanup()
 }
 
+// serve starts s on the given transport ("stdio", "sse", or
+// "streamable-http"), blocking until the server stops or errors.
+//
+// Note: long-running tools (go_test, go_build, go_doc -all) do not yet push
+// $/progress notifications over these transports - today's tool handlers
+// are registered with the argument-only ToolHandlerFunc signature, which
+// has no context/session to notify through. Streaming progress requires
+// moving handlers to the context+request signature first.
+func serve(s *server.MCPServer, transport, addr string) error {
+	switch transport {
+	case "", "stdio":
+		log.Printf("Starting stdio server...")
+		return server.ServeStdio(s)
+	case "sse":
+		log.Printf("Starting SSE server on %s...", addr)
+		return server.NewSSEServer(s).Start(addr)
+	case "streamable-http":
+		log.Printf("Starting streamable-http server on %s...", addr)
+		return server.NewStreamableHTTPServer(s).Start(addr)
+	default:
+		return fmt.Errorf("unknown transport %q: must be \"stdio\", \"sse\", or \"streamable-http\"", transport)
+	}
+}
+
 const toolDescription = `Get Go documentation for a package, type, function, or method.
 This is the preferred and most efficient way to understand Go packages, providing official package
 documentation in a concise format. Use this before attempting to read source files directly. Results
@@ -142,18 +389,28 @@ Common Usage Patterns:
 - External packages: Use full import path (e.g., "github.com/user/repo")
 - Local packages: Use relative path (e.g., "./pkg") or absolute path
 
-The documentation is cached for 5 minutes to improve performance.`
+Results are cached to disk (default 24h TTL, configurable via GO_MCP_CACHE_TTL) to improve performance.`
 
 type GodocServer struct {
 	Workdir string
 	server  *server.MCPServer
-	cache   map[string]cachedDoc
-}
-
-type cachedDoc struct {
-	content   string
-	timestamp time.Time
-	byteSize  int
+	gopls   *GoplsClient
+	runner  *Runner
+
+	// CacheDir, MaxCacheBytes, and CacheTTL configure the persistent
+	// on-disk cache (see cache.go); diskCache is the cache built from them.
+	CacheDir      string
+	MaxCacheBytes int64
+	CacheTTL      time.Duration
+	diskCache     *DiskCache
+
+	// Workspaces holds every module discovered under the configured
+	// workspace roots, letting tools accept a "workspace" argument instead
+	// of always running against Workdir (see workspace.go).
+	Workspaces *WorkspaceManager
+
+	goVersionOnce sync.Once
+	goVersion     string
 }
 
 // createTempProject creates a temporary Go project with the given package
@@ -164,32 +421,30 @@ func isStdLib(pkg string) bool {
 	return !strings.Contains(pkg, ".")
 }
 
-// cleanup removes all temporary directories
+// cleanup stops any managed subprocesses (e.g. the embedded gopls, if it
+// was started).
 func (s *GodocServer) cleanup() {
-
+	if s.gopls != nil {
+		s.gopls.close()
+	}
 }
 
 // runGoDoc executes the go doc command with the given arguments and optional working directory
 func (s *GodocServer) runGoDoc(workingDir string, args ...string) (string, error) {
-	// Create cache key that includes working directory
-	cacheKey := workingDir + "|" + strings.Join(args, "|")
-
-	// Check cache (with 5 minute expiration)
-	// if doc, ok := s.cache[cacheKey]; ok {
-	// 	if time.Since(doc.timestamp) < 5*time.Minute {
-	// 		log.Printf("Cache hit for %s (%d bytes)", cacheKey, doc.byteSize)
-	// 		return doc.content, nil
-	// 	}
-	// }
-
-	cmd := exec.Command("go", append([]string{"doc"}, args...)...)
-	if workingDir != "" {
-		cmd.Dir = workingDir
-	}
-	out, err := cmd.CombinedOutput()
+	cacheKey := ActionKey("doc", args, workingDir, goModHash(workingDir), s.goToolVersion())
+	if content, ok := s.diskCache.Get(cacheKey); ok {
+		log.Printf("Cache hit for %s", cacheKey)
+		return content, nil
+	}
+
+	stdout, stderr, err := s.runner.Run(context.Background(), Invocation{
+		Verb:       "doc",
+		Args:       args,
+		WorkingDir: workingDir,
+	})
 	if err != nil {
 		// Enhanced error handling with suggestions
-		errStr := string(out)
+		errStr := string(stdout) + string(stderr)
 		if strings.Contains(errStr, "no such package") || strings.Contains(errStr, "is not in std") {
 			return "", fmt.Errorf("Package not found. Suggestions:\n"+
 				"1. For standard library packages, use just the package name (e.g., 'io', 'net/http')\n"+
@@ -214,17 +469,38 @@ func (s *GodocServer) runGoDoc(workingDir string, args ...string) (string, error
 		return "", fmt.Errorf("go doc error: %v\noutput: %s\nTip: Use -h flag to see all available options", err, errStr)
 	}
 
-	content := string(out)
-	s.cache[cacheKey] = cachedDoc{
-		content:   content,
-		timestamp: time.Now(),
-		byteSize:  len(content),
+	content := string(stdout)
+	if err := s.diskCache.Put(cacheKey, content); err != nil {
+		log.Printf("cache: failed to store %s: %v", cacheKey, err)
 	}
 
 	log.Printf("Cache miss for %s (%d bytes)", cacheKey, len(content))
 	return content, nil
 }
 
+// goToolVersion returns `go version`'s output, determined once and reused
+// as part of every cache key (so a toolchain upgrade invalidates old
+// entries instead of returning stale output).
+func (s *GodocServer) goToolVersion() string {
+	s.goVersionOnce.Do(func() {
+		stdout, _, err := s.runner.Run(context.Background(), Invocation{Verb: "version"})
+		if err != nil {
+			log.Printf("cache: failed to determine go version: %v", err)
+			return
+		}
+		s.goVersion = strings.TrimSpace(string(stdout))
+	})
+	return s.goVersion
+}
+
+// handleCacheClear implements the go_cache_clear MCP tool.
+func (s *GodocServer) handleCacheClear(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if err := s.diskCache.Clear(); err != nil {
+		return nil, fmt.Errorf("clearing cache: %v", err)
+	}
+	return textResult(fmt.Sprintf("Cache at %s cleared.", s.CacheDir)), nil
+}
+
 // handleGoDoc implements the tools/call endpoint
 func (s *GodocServer) handleGoDoc(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
 	log.Printf("handleToolCall called with arguments: %+v", arguments)
@@ -251,8 +527,13 @@ func (s *GodocServer) handleGoDoc(arguments map[string]interface{}) (*mcp.CallTo
 		}
 	}
 
+	workdir, err := s.resolveWorkdir(arguments)
+	if err != nil {
+		return nil, err
+	}
+
 	// Run go doc command with working directory
-	doc, err := s.runGoDoc(s.Workdir, cmdArgs...)
+	doc, err := s.runGoDoc(workdir, cmdArgs...)
 	if err != nil {
 		log.Printf("Error running go doc: %v", err)
 		return nil, err
@@ -275,6 +556,33 @@ func (s *GodocServer) handleGoDoc(arguments map[string]interface{}) (*mcp.CallTo
 	return result, nil
 }
 
+// runGoList executes the go list command with the given arguments and
+// working directory, going through the same disk cache as runGoDoc.
+func (s *GodocServer) runGoList(workingDir string, args ...string) (string, error) {
+	cacheKey := ActionKey("list", args, workingDir, goModHash(workingDir), s.goToolVersion())
+	if content, ok := s.diskCache.Get(cacheKey); ok {
+		log.Printf("Cache hit for %s", cacheKey)
+		return content, nil
+	}
+
+	stdout, stderr, err := s.runner.Run(context.Background(), Invocation{
+		Verb:       "list",
+		Args:       args,
+		WorkingDir: workingDir,
+	})
+	if err != nil {
+		return "", fmt.Errorf("go list error: %v\noutput: %s", err, string(stderr))
+	}
+
+	content := string(stdout)
+	if err := s.diskCache.Put(cacheKey, content); err != nil {
+		log.Printf("cache: failed to store %s: %v", cacheKey, err)
+	}
+
+	log.Printf("Cache miss for %s (%d bytes)", cacheKey, len(content))
+	return content, nil
+}
+
 // handleGoList implements the tools/call endpoint
 func (s *GodocServer) handleGoList(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
 	log.Printf("handleGoList called with arguments: %+v", arguments)
@@ -291,14 +599,15 @@ func (s *GodocServer) handleGoList(arguments map[string]interface{}) (*mcp.CallT
 		cmdArgs = append(cmdArgs, packages...)
 	}
 
-	// Run go list command with working directory
-	cmd := exec.Command("go", append([]string{"list"}, cmdArgs...)...)
-	if s.Workdir != "" {
-		cmd.Dir = s.Workdir
+	workdir, err := s.resolveWorkdir(arguments)
+	if err != nil {
+		return nil, err
 	}
-	out, err := cmd.CombinedOutput()
+
+	// Run go list command with working directory
+	stdout, err := s.runGoList(workdir, cmdArgs...)
 	if err != nil {
-		return nil, fmt.Errorf("go list error: %v\noutput: %s", err, string(out))
+		return nil, err
 	}
 
 	// Create the result with just the documentation
@@ -306,7 +615,7 @@ func (s *GodocServer) handleGoList(arguments map[string]interface{}) (*mcp.CallT
 		Content: []interface{}{
 			map[string]interface{}{
 				"type": "text",
-				"text": string(out),
+				"text": stdout,
 			},
 		},
 	}
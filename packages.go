@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+// packagesLoadMode is shared by every tool in this file: enough to answer
+// doc/symbol queries against the resulting package graph without shelling
+// out to `go doc`/`go list` per query.
+const packagesLoadMode = packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedSyntax | packages.NeedDeps
+
+// loadPackages loads patterns rooted at workdir with enough information to
+// answer doc/symbol/type queries directly against the syntax and type
+// graph, instead of shelling out to `go doc` per-query. env, if non-nil,
+// overrides the build environment `packages.Load` invokes `go list` with
+// (see crossCompileEnv) so callers can see symbols excluded by build
+// constraints on other platforms.
+func loadPackages(workdir string, env []string, patterns ...string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode: packagesLoadMode,
+		Dir:  workdir,
+		Env:  env,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("loading packages %v: %v", patterns, err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("errors while loading packages %v (see stderr for detail)", patterns)
+	}
+	return pkgs, nil
+}
+
+// crossCompileEnv builds the Env to pass to packages.Config from a tool
+// call's optional "goos"/"goarch" arguments, starting from the current
+// process environment so PATH/HOME/GOCACHE/GOROOT are preserved (see
+// gocommand.go's run, which does the same for plain `go` subprocess
+// invocations). Returns nil if neither argument was given, so
+// packages.Load falls back to its own default (the current environment).
+func crossCompileEnv(arguments map[string]interface{}) []string {
+	goos, _ := getString(arguments, "goos")
+	goarch, _ := getString(arguments, "goarch")
+	if goos == "" && goarch == "" {
+		return nil
+	}
+	env := append([]string{}, os.Environ()...)
+	if goos != "" {
+		env = append(env, "GOOS="+goos)
+	}
+	if goarch != "" {
+		env = append(env, "GOARCH="+goarch)
+	}
+	return env
+}
+
+// crossCompileArgSchema is merged into go_package_info/go_symbol_doc's
+// InputSchema.Properties so callers can inspect symbols that are excluded
+// by build constraints on the current platform.
+var crossCompileArgSchema = map[string]interface{}{
+	"goos": map[string]interface{}{
+		"type":        "string",
+		"description": "Optional: GOOS to load packages for, e.g. \"windows\" or \"js\", to see symbols excluded by build constraints on the current platform. Defaults to the current GOOS.",
+	},
+	"goarch": map[string]interface{}{
+		"type":        "string",
+		"description": "Optional: GOARCH to load packages for, e.g. \"arm64\". Defaults to the current GOARCH.",
+	},
+}
+
+// packageInfo is the JSON shape returned by the go_package_info tool.
+type packageInfo struct {
+	PkgPath    string   `json:"pkgPath"`
+	Name       string   `json:"name"`
+	ModulePath string   `json:"modulePath,omitempty"`
+	GoVersion  string   `json:"goVersion,omitempty"`
+	Files      []string `json:"files"`
+	Imports    []string `json:"imports"`
+}
+
+// handleGoPackageInfo implements the go_package_info MCP tool.
+func (s *GodocServer) handleGoPackageInfo(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	pattern, ok := getString(arguments, "pattern")
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("pattern is required, e.g. \".\" or \"./internal/foo\"")
+	}
+
+	workdir, err := s.resolveWorkdir(arguments)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := loadPackages(workdir, crossCompileEnv(arguments), pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]packageInfo, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		info := packageInfo{
+			PkgPath: pkg.PkgPath,
+			Name:    pkg.Name,
+			Files:   pkg.GoFiles,
+		}
+		if pkg.Module != nil {
+			info.ModulePath = pkg.Module.Path
+			info.GoVersion = pkg.Module.GoVersion
+		}
+		for imp := range pkg.Imports {
+			info.Imports = append(info.Imports, imp)
+		}
+		infos = append(infos, info)
+	}
+
+	data, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling package info: %v", err)
+	}
+	return textResult(string(data)), nil
+}
+
+// symbolDoc is the JSON shape returned by the go_symbol_doc tool.
+type symbolDoc struct {
+	Name      string `json:"name"`
+	PkgPath   string `json:"pkgPath"`
+	Doc       string `json:"doc,omitempty"`
+	Signature string `json:"signature"`
+	File      string `json:"file"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	Source    string `json:"source"`
+}
+
+// handleGoSymbolDoc implements the go_symbol_doc MCP tool: given a
+// "pkg.Sym" selector, it loads pkg with packages.Load and walks its *ast.File
+// syntax trees for the declaration of Sym, returning its doc comment,
+// signature, and source snippet.
+func (s *GodocServer) handleGoSymbolDoc(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	selector, ok := getString(arguments, "symbol")
+	if !ok || selector == "" {
+		return nil, fmt.Errorf("symbol is required, e.g. \"net/http.Client\" or \"./internal/foo.Bar\"")
+	}
+
+	idx := strings.LastIndex(selector, ".")
+	if idx < 0 {
+		return nil, fmt.Errorf("symbol %q must be of the form pkg.Sym", selector)
+	}
+	pattern, name := selector[:idx], selector[idx+1:]
+
+	workdir, err := s.resolveWorkdir(arguments)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := loadPackages(workdir, crossCompileEnv(arguments), pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages matched %q", pattern)
+	}
+	pkg := pkgs[0]
+
+	doc, err := findSymbolDoc(pkg, name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling symbol doc: %v", err)
+	}
+	return textResult(string(data)), nil
+}
+
+// findSymbolDoc walks pkg's syntax trees for a top-level declaration of
+// name and builds a symbolDoc from it.
+func findSymbolDoc(pkg *packages.Package, name string) (*symbolDoc, error) {
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Name.Name != name {
+					continue
+				}
+				return declToSymbolDoc(pkg, name, d.Doc, d, d.Pos(), d.End())
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch sp := spec.(type) {
+					case *ast.TypeSpec:
+						if sp.Name.Name != name {
+							continue
+						}
+						doc := sp.Doc
+						if doc == nil {
+							doc = d.Doc
+						}
+						return declToSymbolDoc(pkg, name, doc, sp, d.Pos(), d.End())
+					case *ast.ValueSpec:
+						for _, id := range sp.Names {
+							if id.Name != name {
+								continue
+							}
+							doc := sp.Doc
+							if doc == nil {
+								doc = d.Doc
+							}
+							return declToSymbolDoc(pkg, name, doc, sp, d.Pos(), d.End())
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("symbol %q not found in package %q", name, pkg.PkgPath)
+}
+
+// declToSymbolDoc renders a symbolDoc from a declaration's doc comment and
+// source span.
+func declToSymbolDoc(pkg *packages.Package, name string, doc *ast.CommentGroup, node ast.Node, start, end token.Pos) (*symbolDoc, error) {
+	fset := pkg.Fset
+	startPos := fset.Position(start)
+	endPos := fset.Position(end)
+
+	signature, err := renderSignature(fset, name, node)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := sourceSnippet(startPos.Filename, startPos.Line, endPos.Line)
+	if err != nil {
+		return nil, err
+	}
+
+	docText := ""
+	if doc != nil {
+		docText = doc.Text()
+	}
+
+	return &symbolDoc{
+		Name:      name,
+		PkgPath:   pkg.PkgPath,
+		Doc:       docText,
+		Signature: signature,
+		File:      startPos.Filename,
+		StartLine: startPos.Line,
+		EndLine:   endPos.Line,
+		Source:    source,
+	}, nil
+}
+
+// renderSignature prints a signature for a func/type/var/const declaration
+// using go/printer. For a func it is the declaration without its body, e.g.
+// "func (c *Client) Do(req *Request) (*Response, error)"; for a type it is
+// the type spec without the "type" keyword, with any struct or interface
+// body printed in full over several lines; for a var/const it is the whole
+// spec, including any values.
+func renderSignature(fset *token.FileSet, name string, node ast.Node) (string, error) {
+	var target ast.Node
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		sig := &ast.FuncDecl{Recv: n.Recv, Name: n.Name, Type: n.Type}
+		target = sig
+	case *ast.TypeSpec:
+		target = &ast.TypeSpec{Name: n.Name, TypeParams: n.TypeParams, Type: n.Type}
+	case *ast.ValueSpec:
+		target = n
+	default:
+		return "", fmt.Errorf("unsupported declaration kind %T for %q", node, name)
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, target); err != nil {
+		return "", fmt.Errorf("printing signature for %q: %v", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// sourceSnippet reads lines [startLine, endLine] (1-based, inclusive) from
+// path.
+func sourceSnippet(path string, startLine, endLine int) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %v", path, err)
+	}
+	lines := strings.Split(string(data), "\n")
+	if startLine < 1 {
+		startLine = 1
+	}
+	if endLine > len(lines) {
+		endLine = len(lines)
+	}
+	if startLine > endLine {
+		return "", nil
+	}
+	return strings.Join(lines[startLine-1:endLine], "\n"), nil
+}
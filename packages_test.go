@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCrossCompileEnvNilWhenUnset(t *testing.T) {
+	if env := crossCompileEnv(map[string]interface{}{}); env != nil {
+		t.Errorf("crossCompileEnv({}) = %v, want nil", env)
+	}
+}
+
+func TestCrossCompileEnvOverridesPreserveParentEnv(t *testing.T) {
+	env := crossCompileEnv(map[string]interface{}{"goos": "windows", "goarch": "arm64"})
+
+	var hasGOOS, hasGOARCH bool
+	for _, kv := range env {
+		if kv == "GOOS=windows" {
+			hasGOOS = true
+		}
+		if kv == "GOARCH=arm64" {
+			hasGOARCH = true
+		}
+	}
+	if !hasGOOS {
+		t.Errorf("crossCompileEnv missing GOOS=windows, got %v", env)
+	}
+	if !hasGOARCH {
+		t.Errorf("crossCompileEnv missing GOARCH=arm64, got %v", env)
+	}
+
+	// The parent environment must still be present underneath the
+	// overrides, or the spawned `go list` loses PATH/HOME/GOCACHE/GOROOT.
+	for _, kv := range os.Environ() {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if key == "GOOS" || key == "GOARCH" {
+			continue
+		}
+		found := false
+		for _, e := range env {
+			if e == kv {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("crossCompileEnv dropped parent env var %q", kv)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// diagnosticLineRegexp matches the "file:line:col: message" format shared by
+// go vet and go build/compile errors.
+var diagnosticLineRegexp = regexp.MustCompile(`^(\S+\.go):(\d+):(\d+):\s*(.+)$`)
+
+// Diagnostic is a single parsed vet/build diagnostic.
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Col      int    `json:"col"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// parseDiagnostics parses `go vet`/`go build` output into structured
+// Diagnostics, one per "file:line:col: message" line. Lines that don't
+// match (summary lines, "# package" headers) are dropped from the
+// structured result but remain in the raw text block.
+func parseDiagnostics(output string) []Diagnostic {
+	var diags []Diagnostic
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		m := diagnosticLineRegexp.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		diags = append(diags, Diagnostic{
+			File:     m[1],
+			Line:     line,
+			Col:      col,
+			Severity: "error",
+			Message:  m[4],
+		})
+	}
+	return diags
+}
+
+// diagnosticsResult builds a CallToolResult with a human-readable text block
+// followed by a JSON block, as used by go_vet and go_build.
+func diagnosticsResult(rawOutput string, diags []Diagnostic) (*mcp.CallToolResult, error) {
+	data, err := json.MarshalIndent(diags, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling diagnostics: %v", err)
+	}
+	return &mcp.CallToolResult{
+		Content: []interface{}{
+			map[string]interface{}{"type": "text", "text": rawOutput},
+			map[string]interface{}{"type": "text", "text": string(data)},
+		},
+	}, nil
+}
+
+// handleGoVet implements the go_vet MCP tool.
+func (s *GodocServer) handleGoVet(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	args, _ := getMapSliceAnyString(arguments, "packages")
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+	workdir, err := s.resolveWorkdir(arguments)
+	if err != nil {
+		return nil, err
+	}
+	stdout, stderr, _ := s.runner.Run(context.Background(), Invocation{
+		Verb:       "vet",
+		Args:       args,
+		WorkingDir: workdir,
+	})
+	raw := string(stdout) + string(stderr)
+	return diagnosticsResult(raw, parseDiagnostics(raw))
+}
+
+// handleGoBuild implements the go_build MCP tool.
+func (s *GodocServer) handleGoBuild(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	args, _ := getMapSliceAnyString(arguments, "packages")
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+	workdir, err := s.resolveWorkdir(arguments)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs := append([]string{"-o", "/dev/null"}, args...)
+	stdout, stderr, _ := s.runner.Run(context.Background(), Invocation{
+		Verb:       "build",
+		Args:       cmdArgs,
+		WorkingDir: workdir,
+	})
+	raw := string(stdout) + string(stderr)
+	return diagnosticsResult(raw, parseDiagnostics(raw))
+}
+
+// testEvent mirrors a single event from `go test -json`, as documented by
+// cmd/test2json.
+type testEvent struct {
+	Action  string  `json:"action"`
+	Package string  `json:"package,omitempty"`
+	Test    string  `json:"test,omitempty"`
+	Elapsed float64 `json:"elapsed,omitempty"`
+	Output  string  `json:"output,omitempty"`
+}
+
+// parseTestEvents decodes a stream of `go test -json` events.
+func parseTestEvents(raw []byte) []testEvent {
+	var events []testEvent
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	for dec.More() {
+		var e testEvent
+		if err := dec.Decode(&e); err != nil {
+			break
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// handleGoTest implements the go_test MCP tool.
+func (s *GodocServer) handleGoTest(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	args, _ := getMapSliceAnyString(arguments, "packages")
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+	workdir, err := s.resolveWorkdir(arguments)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs := append([]string{"-json"}, args...)
+	stdout, stderr, _ := s.runner.Run(context.Background(), Invocation{
+		Verb:       "test",
+		Args:       cmdArgs,
+		WorkingDir: workdir,
+	})
+
+	events := parseTestEvents(stdout)
+	data, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling test events: %v", err)
+	}
+
+	var summary strings.Builder
+	for _, e := range events {
+		if e.Action == "output" {
+			summary.WriteString(e.Output)
+		}
+	}
+	summary.Write(stderr)
+
+	return &mcp.CallToolResult{
+		Content: []interface{}{
+			map[string]interface{}{"type": "text", "text": summary.String()},
+			map[string]interface{}{"type": "text", "text": string(data)},
+		},
+	}, nil
+}
+
+// modGraphEdge is one edge of `go mod graph`: from requires to.
+type modGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// allowedGoModSubcommands is the allow-list enforced by handleGoMod. Every
+// other subcommand, such as "edit", "download", "init" or "vendor", is
+// rejected. Of the allowed ones, "why" and "graph" only read the module;
+// "tidy" rewrites go.mod and go.sum in the target module.
+var allowedGoModSubcommands = map[string]bool{
+	"why":   true,
+	"graph": true,
+	"tidy":  true,
+}
+
+// handleGoMod implements the go_mod MCP tool, covering the `why`, `graph`,
+// and `tidy` subcommands.
+func (s *GodocServer) handleGoMod(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	subcommand, ok := getString(arguments, "subcommand")
+	if !ok || subcommand == "" {
+		return nil, fmt.Errorf("subcommand is required: one of \"why\", \"graph\", \"tidy\"")
+	}
+	if !allowedGoModSubcommands[subcommand] {
+		return nil, fmt.Errorf("unsupported subcommand %q: must be one of \"why\", \"graph\", \"tidy\"", subcommand)
+	}
+	extraArgs, _ := getMapSliceAnyString(arguments, "args")
+
+	workdir, err := s.resolveWorkdir(arguments)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs := append([]string{subcommand}, extraArgs...)
+	stdout, stderr, err := s.runner.Run(context.Background(), Invocation{
+		Verb:       "mod",
+		Args:       cmdArgs,
+		WorkingDir: workdir,
+	})
+	raw := string(stdout) + string(stderr)
+	if err != nil && subcommand != "why" {
+		return nil, fmt.Errorf("go mod %s: %v\noutput: %s", subcommand, err, raw)
+	}
+
+	var structured interface{}
+	switch subcommand {
+	case "graph":
+		var edges []modGraphEdge
+		scanner := bufio.NewScanner(bytes.NewReader(stdout))
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) != 2 {
+				continue
+			}
+			edges = append(edges, modGraphEdge{From: fields[0], To: fields[1]})
+		}
+		structured = edges
+	case "tidy":
+		structured = map[string]string{"status": "ok"}
+	default: // "why" and anything else: no structured shape, just the text
+		structured = map[string]string{"output": string(stdout)}
+	}
+
+	data, err := json.MarshalIndent(structured, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling go mod %s result: %v", subcommand, err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []interface{}{
+			map[string]interface{}{"type": "text", "text": raw},
+			map[string]interface{}{"type": "text", "text": string(data)},
+		},
+	}, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+func TestParseDiagnostics(t *testing.T) {
+	output := `# example.com/foo
+./main.go:10:2: undefined: fmt.Prinln
+./main.go:15:14: missing return
+vet: ./main.go:20:1: exit status 1
+`
+	diags := parseDiagnostics(output)
+	want := []Diagnostic{
+		{File: "./main.go", Line: 10, Col: 2, Severity: "error", Message: "undefined: fmt.Prinln"},
+		{File: "./main.go", Line: 15, Col: 14, Severity: "error", Message: "missing return"},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("parseDiagnostics returned %d diagnostics, want %d: %+v", len(diags), len(want), diags)
+	}
+	for i, d := range diags {
+		if d != want[i] {
+			t.Errorf("diagnostic %d = %+v, want %+v", i, d, want[i])
+		}
+	}
+}
+
+func TestParseDiagnosticsNoMatches(t *testing.T) {
+	if diags := parseDiagnostics("ok\n"); diags != nil {
+		t.Errorf("parseDiagnostics(%q) = %+v, want nil", "ok\n", diags)
+	}
+}
+
+func TestParseTestEvents(t *testing.T) {
+	raw := []byte(`{"Action":"run","Package":"example.com/foo","Test":"TestFoo"}
+{"Action":"output","Package":"example.com/foo","Test":"TestFoo","Output":"--- PASS: TestFoo\n"}
+{"Action":"pass","Package":"example.com/foo","Test":"TestFoo","Elapsed":0.01}
+`)
+	events := parseTestEvents(raw)
+	if len(events) != 3 {
+		t.Fatalf("parseTestEvents returned %d events, want 3: %+v", len(events), events)
+	}
+	if events[0].Action != "run" || events[0].Test != "TestFoo" {
+		t.Errorf("events[0] = %+v, want Action=run Test=TestFoo", events[0])
+	}
+	if events[2].Action != "pass" || events[2].Elapsed != 0.01 {
+		t.Errorf("events[2] = %+v, want Action=pass Elapsed=0.01", events[2])
+	}
+}
+
+func TestParseTestEventsStopsOnInvalidJSON(t *testing.T) {
+	raw := []byte(`{"Action":"run"}
+not json
+{"Action":"pass"}
+`)
+	events := parseTestEvents(raw)
+	if len(events) != 1 {
+		t.Fatalf("parseTestEvents returned %d events, want 1 (stop at first decode error): %+v", len(events), events)
+	}
+}
+
+func TestAllowedGoModSubcommands(t *testing.T) {
+	for _, sub := range []string{"why", "graph", "tidy"} {
+		if !allowedGoModSubcommands[sub] {
+			t.Errorf("allowedGoModSubcommands[%q] = false, want true", sub)
+		}
+	}
+	for _, sub := range []string{"edit", "download", "init", "vendor", ""} {
+		if allowedGoModSubcommands[sub] {
+			t.Errorf("allowedGoModSubcommands[%q] = true, want false", sub)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ModuleInfo describes one discovered Go module.
+type ModuleInfo struct {
+	ImportPath string `json:"importPath"`
+	Dir        string `json:"dir"`
+	GoVersion  string `json:"goVersion,omitempty"`
+}
+
+// WorkspaceManager discovers every go.mod under a set of root directories
+// and lets tools select which module to run against by import path,
+// instead of this server being pinned to a single WORKDIR. This is what
+// makes the server usable against a monorepo or an editor session with
+// multiple modules open at once.
+type WorkspaceManager struct {
+	Roots   []string
+	modules map[string]ModuleInfo // moduleImportPath -> info
+}
+
+// NewWorkspaceManager creates a manager over roots. Call Discover to
+// populate it.
+func NewWorkspaceManager(roots ...string) *WorkspaceManager {
+	return &WorkspaceManager{
+		Roots:   roots,
+		modules: make(map[string]ModuleInfo),
+	}
+}
+
+// Discover walks every root looking for go.mod files and records each one's
+// module path, directory, and declared Go version. It replaces whatever was
+// previously discovered.
+func (w *WorkspaceManager) Discover() error {
+	modules := make(map[string]ModuleInfo)
+	for _, root := range w.Roots {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				name := info.Name()
+				if name != "." && strings.HasPrefix(name, ".") {
+					return filepath.SkipDir
+				}
+				if name == "vendor" || name == "node_modules" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Name() != "go.mod" {
+				return nil
+			}
+			mod, err := parseGoMod(path)
+			if err != nil {
+				return nil // skip unreadable/unparsable go.mod rather than aborting the whole walk
+			}
+			mod.Dir = filepath.Dir(path)
+			modules[mod.ImportPath] = mod
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("walking workspace root %s: %v", root, err)
+		}
+	}
+	w.modules = modules
+	return nil
+}
+
+// parseGoMod extracts the module path and go directive from a go.mod file
+// without requiring golang.org/x/mod/modfile.
+func parseGoMod(path string) (ModuleInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ModuleInfo{}, err
+	}
+	defer f.Close()
+
+	var mod ModuleInfo
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "module "):
+			mod.ImportPath = strings.TrimSpace(strings.TrimPrefix(line, "module "))
+		case strings.HasPrefix(line, "go "):
+			mod.GoVersion = strings.TrimSpace(strings.TrimPrefix(line, "go "))
+		}
+	}
+	if mod.ImportPath == "" {
+		return ModuleInfo{}, fmt.Errorf("%s: no module directive found", path)
+	}
+	return mod, scanner.Err()
+}
+
+// Dir returns the directory of the module with the given import path, or
+// ok=false if it isn't known.
+func (w *WorkspaceManager) Dir(importPath string) (string, bool) {
+	mod, ok := w.modules[importPath]
+	if !ok {
+		return "", false
+	}
+	return mod.Dir, true
+}
+
+// Modules returns every discovered module, in no particular order.
+func (w *WorkspaceManager) Modules() []ModuleInfo {
+	mods := make([]ModuleInfo, 0, len(w.modules))
+	for _, m := range w.modules {
+		mods = append(mods, m)
+	}
+	return mods
+}
+
+// resolveWorkdir picks the working directory for a tool call: the module
+// directory for an explicit "workspace"/"module" argument if one was given,
+// otherwise the server's default Workdir. A module that is not known is an
+// error.
+func (s *GodocServer) resolveWorkdir(arguments map[string]interface{}) (string, error) {
+	module, ok := getString(arguments, "workspace")
+	if !ok || module == "" {
+		module, ok = getString(arguments, "module")
+	}
+	if !ok || module == "" {
+		return s.Workdir, nil
+	}
+	if s.Workspaces == nil {
+		return "", fmt.Errorf("workspace/module argument given but no workspaces were discovered")
+	}
+	dir, ok := s.Workspaces.Dir(module)
+	if !ok {
+		return "", fmt.Errorf("unknown module %q; call go_workspaces to list known modules", module)
+	}
+	return dir, nil
+}
+
+// workspaceArgSchema is merged into the InputSchema.Properties of the tools
+// that run against a module (go_doc, go_list, go_package_info,
+// go_symbol_doc, go_vet, go_build, go_test, go_mod) so callers can target a
+// specific module in a multi-module workspace.
+var workspaceArgSchema = map[string]interface{}{
+	"workspace": map[string]interface{}{
+		"type":        "string",
+		"description": "Optional: the import path of the module to run against (see go_workspaces). Defaults to the server's primary Workdir.",
+	},
+}
+
+// handleGoWorkspaces implements the go_workspaces MCP tool.
+func (s *GodocServer) handleGoWorkspaces(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	var mods []ModuleInfo
+	if s.Workspaces != nil {
+		mods = s.Workspaces.Modules()
+	}
+	data, err := json.MarshalIndent(mods, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling workspaces: %v", err)
+	}
+	return textResult(string(data)), nil
+}